@@ -1,12 +1,17 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/gopikchr/gopikchr"
+	"golang.org/x/term"
 )
 
 /* Testing interface
@@ -19,14 +24,71 @@ func main() {
 	var bSvgOnly bool
 	var bDontStop bool
 	var bDarkMode bool
+	var zFormat string
+	var zOutFile string
+	var bPreview bool
+	var bWatch bool
 	flag.BoolVar(&bSvgOnly, "svg-only", false, "Emit raw SVG without the HTML wrapper")
 	flag.BoolVar(&bDontStop, "dont-stop", false, "Process all files even if earlier files have errors")
 	flag.BoolVar(&bDarkMode, "dark-mode", false, "White-on-Black")
+	flag.StringVar(&zFormat, "format", "svg", "Output format: svg, png, or pdf")
+	flag.StringVar(&zOutFile, "o", "", "Write png or pdf output to this file instead of stdout (required with -format png/pdf for more than one input file, where it is used as a template)")
+	flag.BoolVar(&bPreview, "preview", false, "Write the HTML preview to a temp file and open it in your browser")
+	flag.BoolVar(&bWatch, "watch", false, "With -preview, re-render and reload the page whenever an input file changes")
 
 	options := []gopikchr.Option{gopikchr.WithSVGClass("pikchr")}
 
-	exitCode := false /* Whether to return an error */
-	zStyle := ""      /* Extra styling */
+	flag.Parse()
+	if len(flag.Args()) < 1 {
+		usage(os.Args[0])
+	}
+	format, err := gopikchr.ParseFormat(zFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	zStyle := "" /* Extra styling */
+	if bDarkMode {
+		zStyle = "color:white;background-color:black;"
+		options = append(options, gopikchr.WithDarkMode())
+	}
+	if bSvgOnly {
+		options = append(options, gopikchr.WithPlaintextErrors())
+	}
+	if format != gopikchr.FormatSVG {
+		if bSvgOnly || bPreview {
+			fmt.Fprintf(os.Stderr, "-format %s cannot be combined with -svg-only or -preview\n", format)
+			os.Exit(1)
+		}
+		runRaster(format, zOutFile, bDontStop, flag.Args(), options)
+		return
+	}
+	if zOutFile != "" {
+		fmt.Fprintln(os.Stderr, "-o requires -format png or pdf")
+		os.Exit(1)
+	}
+	if bPreview {
+		if bSvgOnly {
+			fmt.Fprintln(os.Stderr, "-svg-only cannot be combined with -preview")
+			os.Exit(1)
+		}
+		runPreview(flag.Args(), options, bDontStop, zStyle, bWatch)
+		return
+	}
+	out, exitCode := renderHTML(flag.Args(), options, bSvgOnly, bDontStop, zStyle)
+	fmt.Print(out)
+	if exitCode {
+		os.Exit(1)
+	}
+}
+
+/* renderHTML converts each input file and assembles the side-by-side
+** HTML comparison page, or with bSvgOnly just the raw SVG/plaintext
+** diagnostics, returning the rendered output and whether any file
+** failed to convert. */
+func renderHTML(args []string, options []gopikchr.Option, bSvgOnly, bDontStop bool, zStyle string) (string, bool) {
+	var out strings.Builder
+	exitCode := false
 	zHtmlHdr := `<!DOCTYPE html>
 <html lang="en-US">
 <head>
@@ -51,18 +113,7 @@ func main() {
 <body>
 `
 
-	flag.Parse()
-	if len(flag.Args()) < 1 {
-		usage(os.Args[0])
-	}
-	if bDarkMode {
-		zStyle = "color:white;background-color:black;"
-		options = append(options, gopikchr.WithDarkMode())
-	}
-	if bSvgOnly {
-		options = append(options, gopikchr.WithPlaintextErrors())
-	}
-	for i, arg := range flag.Args() {
+	for i, arg := range args {
 		zIn, err := os.ReadFile(arg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -71,24 +122,25 @@ func main() {
 		zOut, w, _, err := gopikchr.Convert(string(zIn), options...)
 		if err != nil {
 			exitCode = true
+			printDiagnostic(arg, err)
 		}
 		if bSvgOnly {
-			fmt.Printf("%s\n", zOut)
+			fmt.Fprintf(&out, "%s\n", zOut)
 		} else {
 			if zHtmlHdr != "" {
-				fmt.Printf("%s", zHtmlHdr)
+				fmt.Fprintf(&out, "%s", zHtmlHdr)
 				zHtmlHdr = ""
 			}
-			fmt.Printf("<h1>File %s</h1>\n", arg)
+			fmt.Fprintf(&out, "<h1>File %s</h1>\n", arg)
 			if err != nil {
-				fmt.Printf("<p>ERROR</p>\n%s\n", zOut)
+				fmt.Fprintf(&out, "<p>ERROR</p>\n%s\n", zOut)
 			} else {
-				fmt.Printf("<div id=\"svg-%d\" onclick=\"toggleHidden('svg-%d')\">\n", i+1, i+1)
-				fmt.Printf("<div style='border:3px solid lightgray;max-width:%dpx;%s'>\n", w, zStyle)
-				fmt.Printf("%s</div>\n", zOut)
-				fmt.Printf("<pre class='hidden'>")
-				print_escape_html(string(zIn))
-				fmt.Printf("</pre>\n</div>\n")
+				fmt.Fprintf(&out, "<div id=\"svg-%d\" onclick=\"toggleHidden('svg-%d')\">\n", i+1, i+1)
+				fmt.Fprintf(&out, "<div style='border:3px solid lightgray;max-width:%dpx;%s'>\n", w, zStyle)
+				fmt.Fprintf(&out, "%s</div>\n", zOut)
+				fmt.Fprintf(&out, "<pre class='hidden'>")
+				escape_html(&out, string(zIn))
+				fmt.Fprintf(&out, "</pre>\n</div>\n")
 			}
 		}
 
@@ -97,13 +149,76 @@ func main() {
 		}
 	}
 	if !bSvgOnly {
-		fmt.Printf("</body></html>\n")
+		fmt.Fprintf(&out, "</body></html>\n")
+	}
+	return out.String(), exitCode
+}
+
+/* Convert each input file straight to a raster format (PNG or PDF) and
+** write it to -o, or to stdout if -o was not given. Raster mode skips
+** the side-by-side HTML comparison page that SVG mode produces, since
+** there is no browser to render it in. With more than one input file,
+** -o is used as a template so each file gets its own path instead of
+** all of them overwriting the same one; -dont-stop is honored the
+** same way it is for the SVG path. */
+func runRaster(format gopikchr.Format, outFile string, bDontStop bool, args []string, options []gopikchr.Option) {
+	if outFile == "" && len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "-format %s requires -o when converting more than one file\n", format)
+		os.Exit(1)
+	}
+	exitCode := false
+	for _, arg := range args {
+		zIn, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			exitCode = true
+			if !bDontStop {
+				break
+			}
+			continue
+		}
+		data, _, _, err := gopikchr.ConvertTo(string(zIn), format, options...)
+		if err != nil {
+			exitCode = true
+			printDiagnostic(arg, err)
+			if !bDontStop {
+				break
+			}
+			continue
+		}
+		if outFile != "" {
+			path := rasterOutputPath(outFile, arg, len(args))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				exitCode = true
+				if !bDontStop {
+					break
+				}
+			}
+		} else {
+			os.Stdout.Write(data)
+		}
 	}
 	if exitCode {
 		os.Exit(1)
 	}
 }
 
+/* rasterOutputPath returns the path to write arg's raster output to.
+** With a single input file, outFile is used as-is. With more than
+** one, each input gets its own path derived from outFile's extension
+** and the input's base name, so that converting several files to the
+** same -o doesn't have them overwrite each other. */
+func rasterOutputPath(outFile, arg string, n int) string {
+	if n == 1 {
+		return outFile
+	}
+	ext := filepath.Ext(outFile)
+	stem := strings.TrimSuffix(outFile, ext)
+	base := strings.TrimSuffix(filepath.Base(arg), filepath.Ext(arg))
+	return fmt.Sprintf("%s-%s%s", stem, base, ext)
+}
+
 /* Print a usage comment for the shell and exit. */
 func usage(argv0 string) {
 	fmt.Fprintf(os.Stderr, "usage: %s [OPTIONS] FILE ...\n", argv0)
@@ -112,10 +227,39 @@ func usage(argv0 string) {
 	os.Exit(1)
 }
 
+/* Print err to stderr, with ANSI color and a "file:line:col" prefix
+** when stderr is a terminal, or plain text otherwise so the output
+** stays friendly to log files and CI consoles. */
+func printDiagnostic(arg string, err error) {
+	var ce *gopikchr.ConvertError
+	if !errors.As(err, &ce) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", arg, err)
+		return
+	}
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		if ce.Line != 0 || ce.Col != 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", arg, ce.Line, ce.Col, ce.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", arg, ce.Message)
+		}
+		return
+	}
+	const (
+		bold = "\x1b[1m"
+		red  = "\x1b[31m"
+		rst  = "\x1b[0m"
+	)
+	if ce.Line != 0 || ce.Col != 0 {
+		fmt.Fprintf(os.Stderr, "%s%s:%d:%d:%s %serror:%s %s\n", bold, arg, ce.Line, ce.Col, rst, red, rst, ce.Message)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s%s:%s %serror:%s %s\n", bold, arg, rst, red, rst, ce.Message)
+	}
+}
+
 var html_re = regexp.MustCompile(`[<>&]`)
 
-/* Send text to standard output, but escape HTML markup */
-func print_escape_html(z string) {
+/* Write text to w, but escape HTML markup */
+func escape_html(w io.Writer, z string) {
 	z = html_re.ReplaceAllStringFunc(z, func(s string) string {
 		switch s {
 		case "<":
@@ -128,5 +272,5 @@ func print_escape_html(z string) {
 			return s
 		}
 	})
-	fmt.Printf("%s", z)
+	fmt.Fprintf(w, "%s", z)
 }
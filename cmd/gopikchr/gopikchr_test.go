@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestRasterOutputPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		outFile string
+		arg     string
+		n       int
+		want    string
+	}{
+		{"single file uses outFile as-is", "out.png", "diagram.pikchr", 1, "out.png"},
+		{"multiple files derive a name per input", "out.png", "diagram.pikchr", 2, "out-diagram.png"},
+		{"input path's own directory is ignored", "out.pdf", "sub/dir/diagram.pikchr", 3, "out-diagram.pdf"},
+		{"outFile without an extension still gets a suffix", "out", "diagram.pikchr", 2, "out-diagram"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rasterOutputPath(tt.outFile, tt.arg, tt.n); got != tt.want {
+				t.Errorf("rasterOutputPath(%q, %q, %d) = %q, want %q", tt.outFile, tt.arg, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gopikchr/gopikchr"
+	"github.com/pkg/browser"
+)
+
+// reloadScript is appended to the preview page in -watch mode. It
+// reloads the page once a second, whether or not the temp file has
+// changed, so newly-rendered content shows up within about a second.
+const reloadScript = "<script>setInterval(function(){ location.reload() }, 1000)</script>\n"
+
+/* runPreview renders args to the HTML comparison page, writes it to a
+** temp file, and opens that file in the user's default browser. With
+** bWatch, it keeps re-rendering and rewriting the same temp file
+** whenever one of the input files changes, and never returns; without
+** it, runPreview renders once and exits like the normal CLI path. */
+func runPreview(args []string, options []gopikchr.Option, bDontStop bool, zStyle string, bWatch bool) {
+	f, err := os.CreateTemp("", "pikchr-preview-*.html")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	path := f.Name()
+	f.Close()
+
+	render := func() bool {
+		out, exitCode := renderHTML(args, options, false, bDontStop, zStyle)
+		if bWatch {
+			out += reloadScript
+		}
+		if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		return exitCode
+	}
+	exitCode := render()
+
+	if err := browser.OpenFile(path); err != nil {
+		fmt.Fprintf(os.Stderr, "opening browser: %v\n", err)
+	}
+
+	if bWatch {
+		watchAndRerender(args, render)
+		return
+	}
+	// There's no reliable cross-platform signal for "the browser has
+	// read the file", so give it a moment before cleaning up the temp
+	// file rather than leaking one on every -preview run.
+	time.Sleep(2 * time.Second)
+	os.Remove(path)
+	if exitCode {
+		os.Exit(1)
+	}
+}
+
+/* watchAndRerender polls the mtimes of args twice a second and calls
+** render again whenever one of them has changed. It never returns:
+** -watch is meant to run for as long as the user is editing the
+** source file, and is killed with the rest of the process. */
+func watchAndRerender(args []string, render func() bool) {
+	mtimes := make(map[string]time.Time, len(args))
+	for _, arg := range args {
+		if fi, err := os.Stat(arg); err == nil {
+			mtimes[arg] = fi.ModTime()
+		}
+	}
+	for range time.Tick(500 * time.Millisecond) {
+		changed := false
+		for _, arg := range args {
+			fi, err := os.Stat(arg)
+			if err != nil {
+				continue
+			}
+			if !fi.ModTime().Equal(mtimes[arg]) {
+				mtimes[arg] = fi.ModTime()
+				changed = true
+			}
+		}
+		if changed {
+			render()
+		}
+	}
+}
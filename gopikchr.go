@@ -4,6 +4,9 @@ package gopikchr
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
 
 	"github.com/gopikchr/gopikchr/internal"
 )
@@ -18,23 +21,83 @@ func Convert(input string, options ...Option) (output string, width int, height
 	var w, h int
 	html := internal.Pikchr(input, conf.class, conf.mFlag, &w, &h)
 	if w == -1 {
-		return html, 0, 0, Error
+		return html, 0, 0, newConvertError(html, conf.mFlag&1 != 0)
 	}
 	return html, w, h, nil
 }
 
+// ConvertError is returned by Convert when the input program fails to
+// parse or render. Unlike the output string (which embeds the
+// diagnostic as HTML or plaintext for human consumption), ConvertError
+// exposes the position and message in structured form so that callers
+// such as editors, CI systems, or language servers can consume it
+// without scraping markup.
+type ConvertError struct {
+	// Line and Col are the 1-based line and column of the token that
+	// triggered the error. They are 0 if pikchr could not determine a
+	// position.
+	Line, Col int
+	// Message is the human-readable diagnostic text.
+	Message string
+	// Raw is the original output produced by the converter (HTML or
+	// plaintext, depending on the options passed to Convert), in case
+	// a caller wants to fall back to displaying it directly.
+	Raw string
+}
+
+// Error implements the error interface.
+func (e *ConvertError) Error() string {
+	if e.Line == 0 && e.Col == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Message)
+}
+
+// posRe matches the "line N column N" position marker that pikchr
+// emits next to an error message, in both its HTML and plaintext
+// error renderings.
+var posRe = regexp.MustCompile(`(?i)line\s+(\d+)\s+column\s+(\d+)`)
+
+// msgRe pulls the diagnostic text out of pikchr's "ERROR: ..." prefix,
+// stripping any HTML tags that may surround it.
+var msgRe = regexp.MustCompile(`(?is)ERROR:\s*(.*?)(?:</p>|\n|$)`)
+var tagRe = regexp.MustCompile(`<[^>]*>`)
+
+// newConvertError builds a ConvertError by parsing the diagnostic that
+// internal.Pikchr already embeds in its output. This is a stopgap
+// until position information is threaded out of the parser directly;
+// see internal.Pikchr.
+func newConvertError(raw string, plaintext bool) *ConvertError {
+	ce := &ConvertError{Raw: raw, Message: raw}
+	if m := posRe.FindStringSubmatch(raw); m != nil {
+		ce.Line, _ = strconv.Atoi(m[1])
+		ce.Col, _ = strconv.Atoi(m[2])
+	}
+	if m := msgRe.FindStringSubmatch(raw); m != nil {
+		ce.Message = tagRe.ReplaceAllString(m[1], "")
+	} else if !plaintext {
+		ce.Message = tagRe.ReplaceAllString(raw, "")
+	}
+	return ce
+}
+
 type config struct {
-	class string
-	mFlag uint
+	class     string
+	mFlag     uint
+	renderers map[Format]Renderer
 }
 
 // Option is the type of gopikchr options, exported so callers can
 // construct slices of options.
 type Option func(o *config)
 
-// Error is the error returned from Convert, to signal that an Error
-// occurred. It's not actually useful: the error information is
-// returned in the output string.
+// Error is not returned by Convert, which returns a *ConvertError on
+// failure instead; it is kept so that code referring to it still
+// compiles.
+//
+// Deprecated: Convert now returns a *ConvertError, which carries the
+// diagnostic position and message instead of requiring callers to
+// scrape them out of the output string. Use errors.As to recover one.
 var Error = errors.New("an error occurred converting the pikchr diagram; see output for details")
 
 // WithSVGClass causes the given class to be added to the <svg> markup.
@@ -0,0 +1,53 @@
+package gopikchr
+
+import "testing"
+
+func TestNewConvertError(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		plaintext bool
+		wantLine  int
+		wantCol   int
+		wantMsg   string
+	}{
+		{
+			name:      "html with position",
+			raw:       `<div class="pikchr-error"><p>ERROR: line 3 column 5: unrecognized token "Foo"</p></div>`,
+			plaintext: false,
+			wantLine:  3,
+			wantCol:   5,
+			wantMsg:   `line 3 column 5: unrecognized token "Foo"`,
+		},
+		{
+			name:      "plaintext with position",
+			raw:       "ERROR: line 2 column 10: unexpected end of input\n",
+			plaintext: true,
+			wantLine:  2,
+			wantCol:   10,
+			wantMsg:   "line 2 column 10: unexpected end of input",
+		},
+		{
+			name:      "html without ERROR prefix or position falls back to stripped tags",
+			raw:       "<p>something went wrong</p>",
+			plaintext: false,
+			wantLine:  0,
+			wantCol:   0,
+			wantMsg:   "something went wrong",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := newConvertError(tt.raw, tt.plaintext)
+			if ce.Line != tt.wantLine || ce.Col != tt.wantCol {
+				t.Errorf("newConvertError(%q) position = %d:%d, want %d:%d", tt.raw, ce.Line, ce.Col, tt.wantLine, tt.wantCol)
+			}
+			if ce.Message != tt.wantMsg {
+				t.Errorf("newConvertError(%q).Message = %q, want %q", tt.raw, ce.Message, tt.wantMsg)
+			}
+			if ce.Raw != tt.raw {
+				t.Errorf("newConvertError(%q).Raw = %q, want original raw", tt.raw, ce.Raw)
+			}
+		})
+	}
+}
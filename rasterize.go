@@ -0,0 +1,191 @@
+package gopikchr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// Format identifies the output format produced by ConvertTo.
+type Format int
+
+const (
+	// FormatSVG is the format Convert already produces.
+	FormatSVG Format = iota
+	// FormatPNG rasterizes the SVG output to a PNG image.
+	FormatPNG
+	// FormatPDF embeds a rasterized image of the diagram in a
+	// single-page PDF sized to match it.
+	FormatPDF
+)
+
+// String returns the lower-case name used for the format by the -format
+// CLI flag, e.g. "svg", "png", "pdf".
+func (f Format) String() string {
+	switch f {
+	case FormatSVG:
+		return "svg"
+	case FormatPNG:
+		return "png"
+	case FormatPDF:
+		return "pdf"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat parses "svg", "png", or "pdf" into a Format.
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "svg":
+		return FormatSVG, nil
+	case "png":
+		return FormatPNG, nil
+	case "pdf":
+		return FormatPDF, nil
+	}
+	return 0, fmt.Errorf("gopikchr: unknown format %q", s)
+}
+
+// Renderer encodes the SVG that Convert produces into another output
+// format. It is the extension point ConvertTo uses for FormatPNG and
+// FormatPDF: a caller that wants a backend other than the built-in
+// pure-Go rasterizer (a test double, or one backed by a specific
+// renderer binary) can register one with WithRenderer instead of
+// forking ConvertTo. There is no equivalent extension point for SVG
+// output itself, since that text is emitted by internal.Pikchr, which
+// this package does not implement.
+type Renderer interface {
+	Render(svg string, width, height int) ([]byte, error)
+}
+
+// RendererFunc adapts a plain function to a Renderer.
+type RendererFunc func(svg string, width, height int) ([]byte, error)
+
+// Render calls f.
+func (f RendererFunc) Render(svg string, width, height int) ([]byte, error) {
+	return f(svg, width, height)
+}
+
+// WithRenderer registers r as the Renderer that ConvertTo uses for
+// format, in place of the built-in one.
+func WithRenderer(format Format, r Renderer) Option {
+	return func(o *config) {
+		if o.renderers == nil {
+			o.renderers = make(map[Format]Renderer)
+		}
+		o.renderers[format] = r
+	}
+}
+
+// builtinRenderers holds the Renderer registered by default for each
+// non-SVG Format.
+var builtinRenderers = map[Format]Renderer{
+	FormatPNG: RendererFunc(renderPNG),
+	FormatPDF: RendererFunc(renderPDF),
+}
+
+// ConvertTo converts a pikchr program into SVG, PNG, or PDF, returning
+// the encoded bytes and the diagram's pixel dimensions. PNG and PDF
+// are produced by running the SVG that Convert already emits through
+// the Renderer registered for format: by default a pure-Go rasterizer
+// (no cgo or external tool such as inkscape is shelled out to), or
+// whatever a WithRenderer option substituted.
+func ConvertTo(input string, format Format, options ...Option) (output []byte, width int, height int, err error) {
+	conf := &config{}
+	for _, o := range options {
+		o(conf)
+	}
+	svg, w, h, err := Convert(input, options...)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if format == FormatSVG {
+		return []byte(svg), w, h, nil
+	}
+	r := conf.renderers[format]
+	if r == nil {
+		r = builtinRenderers[format]
+	}
+	if r == nil {
+		return nil, 0, 0, fmt.Errorf("gopikchr: unsupported format %v", format)
+	}
+	data, err := r.Render(svg, w, h)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return data, w, h, nil
+}
+
+// renderPNG is the built-in Renderer for FormatPNG.
+func renderPNG(svg string, w, h int) ([]byte, error) {
+	img, err := rasterizeSVG(svg, w, h)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("gopikchr: encoding png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderPDF is the built-in Renderer for FormatPDF.
+func renderPDF(svg string, w, h int) ([]byte, error) {
+	img, err := rasterizeSVG(svg, w, h)
+	if err != nil {
+		return nil, err
+	}
+	return rasterToPDF(img, w, h)
+}
+
+// rasterizeSVG rasterizes an SVG document, as produced by Convert, into
+// an in-memory RGBA image of the given pixel dimensions, against a
+// white background.
+func rasterizeSVG(svg string, w, h int) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(strings.NewReader(svg))
+	if err != nil {
+		return nil, fmt.Errorf("gopikchr: parsing svg for rasterization: %w", err)
+	}
+	icon.SetTarget(0, 0, float64(w), float64(h))
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	scanner := rasterx.NewScannerGV(w, h, img, img.Bounds())
+	raster := rasterx.NewDasher(w, h, scanner)
+	icon.Draw(raster, 1.0)
+	return img, nil
+}
+
+// rasterToPDF embeds a rasterized diagram into a single-page PDF whose
+// page size (in points) matches the image dimensions.
+func rasterToPDF(img image.Image, w, h int) ([]byte, error) {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr: "pt",
+		Size:    gofpdf.SizeType{Wd: float64(w), Ht: float64(h)},
+	})
+	pdf.AddPage()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("gopikchr: encoding intermediate png for pdf: %w", err)
+	}
+	opt := gofpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("diagram", opt, &buf)
+	pdf.ImageOptions("diagram", 0, 0, float64(w), float64(h), false, opt, 0, "")
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, fmt.Errorf("gopikchr: writing pdf: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package gopikchr
+
+import "testing"
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{"svg", FormatSVG, false},
+		{"png", FormatPNG, false},
+		{"pdf", FormatPDF, false},
+		{"jpeg", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFormat(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseFormat(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	tests := []struct {
+		f    Format
+		want string
+	}{
+		{FormatSVG, "svg"},
+		{FormatPNG, "png"},
+		{FormatPDF, "pdf"},
+		{Format(99), "Format(99)"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.String(); got != tt.want {
+			t.Errorf("Format(%d).String() = %q, want %q", tt.f, got, tt.want)
+		}
+	}
+}